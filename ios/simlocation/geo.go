@@ -0,0 +1,63 @@
+package simlocation
+
+import "math"
+
+// earthRadiusMeters is the mean Earth radius used for the haversine and
+// destination-point calculations below.
+const earthRadiusMeters = 6371000.0
+
+// haversineDistanceMeters returns the great-circle distance between two
+// points given in decimal degrees.
+func haversineDistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	deltaPhi := (lat2 - lat1) * math.Pi / 180
+	deltaLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return earthRadiusMeters * c
+}
+
+// initialBearingRadians returns the initial bearing, in radians, of the
+// great-circle path from (lat1, lon1) to (lat2, lon2).
+func initialBearingRadians(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	deltaLambda := (lon2 - lon1) * math.Pi / 180
+
+	y := math.Sin(deltaLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(deltaLambda)
+
+	return math.Atan2(y, x)
+}
+
+// destinationPoint returns the point reached by travelling distanceMeters
+// from (lat, lon) along the given bearing (radians), using the standard
+// spherical destination-point formula.
+func destinationPoint(lat, lon, bearingRadians, distanceMeters float64) (float64, float64) {
+	phi1 := lat * math.Pi / 180
+	lambda1 := lon * math.Pi / 180
+	delta := distanceMeters / earthRadiusMeters
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(bearingRadians))
+	lambda2 := lambda1 + math.Atan2(
+		math.Sin(bearingRadians)*math.Sin(delta)*math.Cos(phi1),
+		math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2),
+	)
+
+	return phi2 * 180 / math.Pi, lambda2 * 180 / math.Pi
+}
+
+// interpolateGreatCircle returns the point a fraction (0..1) of the way
+// along the great-circle path from (lat1, lon1) to (lat2, lon2).
+func interpolateGreatCircle(lat1, lon1, lat2, lon2, fraction float64) (float64, float64) {
+	distance := haversineDistanceMeters(lat1, lon1, lat2, lon2)
+	if distance == 0 {
+		return lat1, lon1
+	}
+	bearing := initialBearingRadians(lat1, lon1, lat2, lon2)
+	return destinationPoint(lat1, lon1, bearing, distance*fraction)
+}
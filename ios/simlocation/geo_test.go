@@ -0,0 +1,67 @@
+package simlocation
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestHaversineDistanceMeters(t *testing.T) {
+	tests := []struct {
+		name                        string
+		lat1, lon1, lat2, lon2      float64
+		wantMeters, toleranceMeters float64
+	}{
+		{"same point", 52.520008, 13.404954, 52.520008, 13.404954, 0, 1e-6},
+		{"one degree of longitude at the equator", 0, 0, 0, 1, 111194.93, 1},
+		{"one degree of latitude", 0, 0, 1, 0, 111194.93, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := haversineDistanceMeters(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if !almostEqual(got, tt.wantMeters, tt.toleranceMeters) {
+				t.Errorf("haversineDistanceMeters(%v, %v, %v, %v) = %v, want %v +/- %v",
+					tt.lat1, tt.lon1, tt.lat2, tt.lon2, got, tt.wantMeters, tt.toleranceMeters)
+			}
+		})
+	}
+}
+
+func TestDestinationPoint(t *testing.T) {
+	distance := haversineDistanceMeters(0, 0, 0, 1)
+
+	lat, lon := destinationPoint(0, 0, math.Pi/2, distance)
+
+	if !almostEqual(lat, 0, 1e-6) {
+		t.Errorf("lat = %v, want ~0", lat)
+	}
+	if !almostEqual(lon, 1, 1e-6) {
+		t.Errorf("lon = %v, want ~1", lon)
+	}
+}
+
+func TestInterpolateGreatCircle(t *testing.T) {
+	lat, lon := interpolateGreatCircle(0, 0, 0, 2, 0.5)
+
+	if !almostEqual(lat, 0, 1e-6) {
+		t.Errorf("lat = %v, want ~0", lat)
+	}
+	if !almostEqual(lon, 1, 1e-6) {
+		t.Errorf("lon = %v, want ~1", lon)
+	}
+
+	// Fraction 0 and 1 should return the endpoints.
+	startLat, startLon := interpolateGreatCircle(0, 0, 0, 2, 0)
+	if !almostEqual(startLat, 0, 1e-6) || !almostEqual(startLon, 0, 1e-6) {
+		t.Errorf("fraction 0 = (%v, %v), want (0, 0)", startLat, startLon)
+	}
+
+	endLat, endLon := interpolateGreatCircle(0, 0, 0, 2, 1)
+	if !almostEqual(endLat, 0, 1e-6) || !almostEqual(endLon, 2, 1e-6) {
+		t.Errorf("fraction 1 = (%v, %v), want (0, 2)", endLat, endLon)
+	}
+}
@@ -0,0 +1,235 @@
+package simlocation
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	ios "github.com/danielpaulus/go-ios/ios"
+	log "github.com/sirupsen/logrus"
+)
+
+// MQTTOptions configures SetLocationMQTT's connection to the broker and how
+// incoming Owntracks messages are routed to attached devices.
+type MQTTOptions struct {
+	// Broker is the broker URL, e.g. "tcp://localhost:1883" or "ssl://host:8883".
+	Broker string
+	// Topic is the subscription pattern, e.g. "owntracks/+/+".
+	Topic string
+	// ClientID identifies this subscriber to the broker. Defaults to
+	// "go-ios-simlocation" if empty.
+	ClientID string
+	Username string
+	Password string
+
+	// CAFile, CertFile and KeyFile configure TLS when the broker requires
+	// client certificates. All are optional; when none are set the broker's
+	// scheme determines whether TLS is used at all.
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	// Devices maps the concrete topic a message arrived on to the device it
+	// should drive, so one broker subscription can fan out to several
+	// attached iPhones. A topic with no entry falls back to DefaultDevice.
+	Devices map[string]ios.DeviceEntry
+	// DefaultDevice receives updates for any topic not found in Devices.
+	DefaultDevice ios.DeviceEntry
+
+	// Context allows stopping SetLocationMQTT and disconnecting cleanly.
+	Context context.Context
+}
+
+// ownTracksMessage is the subset of the Owntracks location message
+// (https://owntracks.org/booklet/tech/json/#_typelocation) we act on.
+type ownTracksMessage struct {
+	Type string  `json:"_type"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	Acc  float64 `json:"acc"`
+	Tst  int64   `json:"tst"`
+}
+
+type lastDeviceFix struct {
+	lat float64
+	lon float64
+	acc float64
+}
+
+// SetLocationMQTT subscribes to an MQTT broker and forwards every Owntracks
+// location message it receives to the simulatelocation service of the device
+// the message's topic resolves to, via opts.Devices/DefaultDevice. It blocks
+// until opts.Context is cancelled; a lost broker connection does not end the
+// call, since the client is set to reconnect automatically.
+func SetLocationMQTT(device ios.DeviceEntry, opts MQTTOptions) error {
+	if opts.DefaultDevice.Properties.SerialNumber == "" {
+		opts.DefaultDevice = device
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tlsConfig, err := buildMQTTTLSConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	clientID := opts.ClientID
+	if clientID == "" {
+		clientID = "go-ios-simlocation"
+	}
+
+	clientOpts := mqtt.NewClientOptions().
+		AddBroker(opts.Broker).
+		SetClientID(clientID).
+		SetUsername(opts.Username).
+		SetPassword(opts.Password).
+		SetAutoReconnect(true)
+	if tlsConfig != nil {
+		clientOpts.SetTLSConfig(tlsConfig)
+	}
+
+	router := &mqttRouter{
+		opts:  opts,
+		fixes: make(map[string]lastDeviceFix),
+		conns: make(map[string]*Connection),
+	}
+	defer router.closeAll()
+
+	clientOpts.SetDefaultPublishHandler(router.handleMessage)
+
+	client := mqtt.NewClient(clientOpts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("connecting to mqtt broker %s: %w", opts.Broker, token.Error())
+	}
+	defer client.Disconnect(250)
+
+	if token := client.Subscribe(opts.Topic, 1, router.handleMessage); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("subscribing to %s: %w", opts.Topic, token.Error())
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// mqttRouter holds the per-device debounce state and long-lived device
+// connections for the lifetime of one SetLocationMQTT call.
+type mqttRouter struct {
+	opts  MQTTOptions
+	mu    sync.Mutex
+	fixes map[string]lastDeviceFix
+	conns map[string]*Connection
+}
+
+func (r *mqttRouter) handleMessage(_ mqtt.Client, msg mqtt.Message) {
+	var parsed ownTracksMessage
+	if err := json.Unmarshal(msg.Payload(), &parsed); err != nil {
+		log.WithFields(log.Fields{"topic": msg.Topic(), "error": err}).
+			Warn("discarding unparseable mqtt location message")
+		return
+	}
+	if parsed.Type != "" && parsed.Type != "location" {
+		return
+	}
+
+	device, ok := r.opts.Devices[msg.Topic()]
+	if !ok {
+		device = r.opts.DefaultDevice
+	}
+	udid := device.Properties.SerialNumber
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last, ok := r.fixes[udid]; ok {
+		if debounceOwntracksFix(last, parsed) {
+			return
+		}
+	}
+
+	conn, err := r.connectionFor(udid, device)
+	if err != nil {
+		log.WithFields(log.Fields{"device_udid": udid, "error": err}).
+			Warn("could not connect to simulatelocation service for mqtt update")
+		return
+	}
+
+	if err := conn.Push(parsed.Lat, parsed.Lon); err != nil {
+		log.WithFields(log.Fields{"device_udid": udid, "error": err}).
+			Warn("failed to push mqtt location update")
+		return
+	}
+
+	r.fixes[udid] = lastDeviceFix{lat: parsed.Lat, lon: parsed.Lon, acc: parsed.Acc}
+}
+
+func (r *mqttRouter) connectionFor(udid string, device ios.DeviceEntry) (*Connection, error) {
+	if conn, ok := r.conns[udid]; ok {
+		return conn, nil
+	}
+	conn, err := New(device)
+	if err != nil {
+		return nil, err
+	}
+	r.conns[udid] = conn
+	return conn, nil
+}
+
+func (r *mqttRouter) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, conn := range r.conns {
+		conn.Close()
+	}
+}
+
+// debounceOwntracksFix reports whether fix should be dropped: it is dropped
+// when its accuracy is worse (a larger radius) than the previous fix and the
+// previous fix still lies within the new, larger accuracy circle, i.e. the
+// new point adds no information over the old one.
+func debounceOwntracksFix(previous lastDeviceFix, fix ownTracksMessage) bool {
+	if fix.Acc <= previous.acc {
+		return false
+	}
+
+	distance := haversineDistanceMeters(previous.lat, previous.lon, fix.Lat, fix.Lon)
+	return distance <= fix.Acc
+}
+
+func buildMQTTTLSConfig(opts MQTTOptions) (*tls.Config, error) {
+	if opts.CAFile == "" && opts.CertFile == "" && opts.KeyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if opts.CAFile != "" {
+		caCert, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading mqtt ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificates found in %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading mqtt client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,42 @@
+package simlocation
+
+import "testing"
+
+func TestDebounceOwntracksFix(t *testing.T) {
+	previous := lastDeviceFix{lat: 0, lon: 0, acc: 10}
+
+	tests := []struct {
+		name string
+		fix  ownTracksMessage
+		want bool
+	}{
+		{
+			name: "better accuracy is never dropped",
+			fix:  ownTracksMessage{Lat: 0, Lon: 0.01, Acc: 5},
+			want: false,
+		},
+		{
+			name: "worse accuracy but previous fix still inside the new circle is dropped",
+			fix:  ownTracksMessage{Lat: 0, Lon: 0.0001, Acc: 20},
+			want: true,
+		},
+		{
+			name: "worse accuracy but far enough away is kept",
+			fix:  ownTracksMessage{Lat: 0, Lon: 0.01, Acc: 20},
+			want: false,
+		},
+		{
+			name: "equal accuracy is never dropped",
+			fix:  ownTracksMessage{Lat: 0, Lon: 0.01, Acc: 10},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := debounceOwntracksFix(previous, tt.fix); got != tt.want {
+				t.Errorf("debounceOwntracksFix(%+v, %+v) = %v, want %v", previous, tt.fix, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package simlocation
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	ios "github.com/danielpaulus/go-ios/ios"
+	log "github.com/sirupsen/logrus"
+)
+
+// NMEAFix holds the parsed contents of a GPRMC/GPGGA/GPVTG sentence group,
+// passed to the optional NMEAFixObserver so callers can inspect values the
+// simulatelocation service itself has no use for (course, speed, HDOP, ...).
+type NMEAFix struct {
+	Lat       float64
+	Lon       float64
+	Valid     bool
+	Time      time.Time
+	CourseDeg float64
+	SpeedKnts float64
+	HDOP      float64
+}
+
+// NMEAFixObserver is invoked for every parsed fix, regardless of whether the
+// sentence's status marked it valid.
+type NMEAFixObserver func(fix NMEAFix)
+
+// SetLocationNMEA streams a live NMEA 0183 feed (from a serial GPS, a file, or
+// a TCP source) into the device's simulated location service. It recognizes
+// $GPRMC, $GPGGA and $GPVTG sentences, pacing updates using the delta between
+// successive RMC UTC timestamps, the same way SetLocationGPX paces trackpoints.
+// A nil observer is fine if the caller only cares about the location updates.
+func SetLocationNMEA(device ios.DeviceEntry, r io.Reader, observer NMEAFixObserver) error {
+	locationConn, err := New(device)
+	if err != nil {
+		return err
+	}
+	defer locationConn.Close()
+
+	var lastFixTime time.Time
+	var pending NMEAFix
+	haveRMC := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		sentence := strings.TrimSpace(scanner.Text())
+		if sentence == "" {
+			continue
+		}
+
+		fields, err := verifyAndSplitNMEA(sentence)
+		if err != nil {
+			log.WithFields(log.Fields{"sentence": sentence, "error": err}).
+				Warn("skipping invalid NMEA sentence")
+			continue
+		}
+
+		switch fields[0] {
+		case "$GPRMC":
+			fix, fixTime, err := parseGPRMC(fields)
+			if err != nil {
+				return fmt.Errorf("parsing $GPRMC: %w", err)
+			}
+
+			if haveRMC && !lastFixTime.IsZero() {
+				if d := fixTime.Sub(lastFixTime); d > 0 {
+					time.Sleep(d)
+				}
+			}
+			lastFixTime = fixTime
+			haveRMC = true
+
+			pending = fix
+			if observer != nil {
+				observer(pending)
+			}
+
+			if !fix.Valid {
+				continue
+			}
+
+			if err := locationConn.Push(fix.Lat, fix.Lon); err != nil {
+				return err
+			}
+		case "$GPGGA":
+			lat, lon, err := parseGGALatLon(fields)
+			if err != nil {
+				return fmt.Errorf("parsing $GPGGA: %w", err)
+			}
+			pending.Lat = lat
+			pending.Lon = lon
+			if hdop, err := strconv.ParseFloat(fields[8], 64); err == nil {
+				pending.HDOP = hdop
+			}
+			if observer != nil {
+				observer(pending)
+			}
+		case "$GPVTG":
+			course, speed, err := parseVTG(fields)
+			if err != nil {
+				return fmt.Errorf("parsing $GPVTG: %w", err)
+			}
+			pending.CourseDeg = course
+			pending.SpeedKnts = speed
+			if observer != nil {
+				observer(pending)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// verifyAndSplitNMEA checks the XOR checksum after '*' and splits the
+// sentence into its comma-separated fields, with the checksum stripped.
+func verifyAndSplitNMEA(sentence string) ([]string, error) {
+	if !strings.HasPrefix(sentence, "$") {
+		return nil, errors.New("sentence does not start with '$'")
+	}
+
+	star := strings.LastIndex(sentence, "*")
+	if star == -1 || star+3 > len(sentence) {
+		return nil, errors.New("sentence has no trailing checksum")
+	}
+
+	body := sentence[1:star]
+	wantChecksum, err := strconv.ParseUint(sentence[star+1:star+3], 16, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum digits: %w", err)
+	}
+
+	var checksum byte
+	for i := 0; i < len(body); i++ {
+		checksum ^= body[i]
+	}
+	if checksum != byte(wantChecksum) {
+		return nil, fmt.Errorf("checksum mismatch: got %02X want %02X", checksum, wantChecksum)
+	}
+
+	fields := strings.Split(body, ",")
+	fields[0] = "$" + fields[0]
+	return fields, nil
+}
+
+func parseGPRMC(fields []string) (NMEAFix, time.Time, error) {
+	if len(fields) < 10 {
+		return NMEAFix{}, time.Time{}, errors.New("too few fields")
+	}
+
+	lat, err := parseNMEACoordinate(fields[3], fields[4])
+	if err != nil {
+		return NMEAFix{}, time.Time{}, err
+	}
+	lon, err := parseNMEACoordinate(fields[5], fields[6])
+	if err != nil {
+		return NMEAFix{}, time.Time{}, err
+	}
+
+	speed, _ := strconv.ParseFloat(fields[7], 64)
+	course, _ := strconv.ParseFloat(fields[8], 64)
+
+	fixTime, err := parseNMEATimestamp(fields[1], fields[9])
+	if err != nil {
+		return NMEAFix{}, time.Time{}, err
+	}
+
+	return NMEAFix{
+		Lat:       lat,
+		Lon:       lon,
+		Valid:     fields[2] == "A",
+		Time:      fixTime,
+		SpeedKnts: speed,
+		CourseDeg: course,
+	}, fixTime, nil
+}
+
+func parseGGALatLon(fields []string) (lat float64, lon float64, err error) {
+	if len(fields) < 9 {
+		return 0, 0, errors.New("too few fields")
+	}
+	lat, err = parseNMEACoordinate(fields[2], fields[3])
+	if err != nil {
+		return 0, 0, err
+	}
+	lon, err = parseNMEACoordinate(fields[4], fields[5])
+	if err != nil {
+		return 0, 0, err
+	}
+	return lat, lon, nil
+}
+
+func parseVTG(fields []string) (courseDeg float64, speedKnts float64, err error) {
+	if len(fields) < 6 {
+		return 0, 0, errors.New("too few fields")
+	}
+	courseDeg, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid course: %w", err)
+	}
+	speedKnts, err = strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid speed: %w", err)
+	}
+	return courseDeg, speedKnts, nil
+}
+
+// parseNMEACoordinate decodes a ddmm.mmmm (or dddmm.mmmm for longitude)
+// coordinate together with its N/S/E/W hemisphere letter into signed degrees.
+func parseNMEACoordinate(raw string, hemisphere string) (float64, error) {
+	if raw == "" || hemisphere == "" {
+		return 0, errors.New("empty coordinate")
+	}
+
+	dotIdx := strings.Index(raw, ".")
+	if dotIdx < 2 {
+		return 0, fmt.Errorf("malformed coordinate %q", raw)
+	}
+
+	degreeDigits := dotIdx - 2
+	degrees, err := strconv.ParseFloat(raw[:degreeDigits], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid degrees in %q: %w", raw, err)
+	}
+	minutes, err := strconv.ParseFloat(raw[degreeDigits:], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid minutes in %q: %w", raw, err)
+	}
+
+	value := degrees + minutes/60
+
+	switch hemisphere {
+	case "S", "W":
+		value = -value
+	case "N", "E":
+		// already positive
+	default:
+		return 0, fmt.Errorf("unknown hemisphere %q", hemisphere)
+	}
+
+	return value, nil
+}
+
+// parseNMEATimestamp combines the RMC UTC time (hhmmss.sss) and date
+// (ddmmyy) fields into a time.Time so callers can compute deltas between fixes.
+func parseNMEATimestamp(utcTime string, date string) (time.Time, error) {
+	if len(utcTime) < 6 || len(date) != 6 {
+		return time.Time{}, fmt.Errorf("malformed time/date %q/%q", utcTime, date)
+	}
+
+	day, err := strconv.Atoi(date[0:2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid day in %q: %w", date, err)
+	}
+	month, err := strconv.Atoi(date[2:4])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month in %q: %w", date, err)
+	}
+	year, err := strconv.Atoi(date[4:6])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid year in %q: %w", date, err)
+	}
+
+	hour, err := strconv.Atoi(utcTime[0:2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid hour in %q: %w", utcTime, err)
+	}
+	minute, err := strconv.Atoi(utcTime[2:4])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid minute in %q: %w", utcTime, err)
+	}
+	seconds, err := strconv.ParseFloat(utcTime[4:], 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid seconds in %q: %w", utcTime, err)
+	}
+
+	whole := int(seconds)
+	nanos := int((seconds - float64(whole)) * float64(time.Second))
+
+	// RMC carries a two-digit year: 80-99 are read as 1980-1999 (GPS time
+	// starts in 1980), 00-79 as 2000-2079.
+	century := 2000
+	if year >= 80 {
+		century = 1900
+	}
+
+	return time.Date(century+year, time.Month(month), day, hour, minute, whole, nanos, time.UTC), nil
+}
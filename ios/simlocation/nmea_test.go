@@ -0,0 +1,126 @@
+package simlocation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyAndSplitNMEA(t *testing.T) {
+	fields, err := verifyAndSplitNMEA("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := fields[0], "$GPRMC"; got != want {
+		t.Errorf("fields[0] = %q, want %q", got, want)
+	}
+	if got, want := len(fields), 12; got != want {
+		t.Errorf("len(fields) = %d, want %d", got, want)
+	}
+
+	if _, err := verifyAndSplitNMEA("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*00"); err == nil {
+		t.Error("expected checksum mismatch error, got nil")
+	}
+
+	if _, err := verifyAndSplitNMEA("GPRMC,123519*00"); err == nil {
+		t.Error("expected error for sentence missing '$' prefix, got nil")
+	}
+}
+
+func TestParseNMEACoordinate(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		hemisphere string
+		want       float64
+	}{
+		{"north latitude", "4807.038", "N", 48 + 7.038/60},
+		{"west longitude", "01131.000", "W", -(11 + 31.0/60)},
+		{"south latitude", "4807.038", "S", -(48 + 7.038/60)},
+		{"east longitude", "01131.000", "E", 11 + 31.0/60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNMEACoordinate(tt.raw, tt.hemisphere)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := got - tt.want; diff > 1e-6 || diff < -1e-6 {
+				t.Errorf("parseNMEACoordinate(%q, %q) = %v, want %v", tt.raw, tt.hemisphere, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseNMEACoordinate("4807.038", "Q"); err == nil {
+		t.Error("expected error for unknown hemisphere, got nil")
+	}
+}
+
+func TestParseNMEATimestamp(t *testing.T) {
+	got, err := parseNMEATimestamp("123519", "230394")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := time.Date(1994, time.March, 23, 12, 35, 19, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("parseNMEATimestamp(\"123519\", \"230394\") = %v, want %v", got, want)
+	}
+}
+
+func TestParseGPRMC(t *testing.T) {
+	fields, err := verifyAndSplitNMEA("$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A")
+	if err != nil {
+		t.Fatalf("unexpected error splitting sentence: %v", err)
+	}
+
+	fix, fixTime, err := parseGPRMC(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !fix.Valid {
+		t.Error("expected fix.Valid to be true for status 'A'")
+	}
+
+	wantTime := time.Date(1994, time.March, 23, 12, 35, 19, 0, time.UTC)
+	if !fixTime.Equal(wantTime) {
+		t.Errorf("fixTime = %v, want %v", fixTime, wantTime)
+	}
+	if !fix.Time.Equal(wantTime) {
+		t.Errorf("fix.Time = %v, want %v", fix.Time, wantTime)
+	}
+}
+
+func TestParseVTG(t *testing.T) {
+	fields, err := verifyAndSplitNMEA("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48")
+	if err != nil {
+		t.Fatalf("unexpected error splitting sentence: %v", err)
+	}
+
+	course, speed, err := parseVTG(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !almostEqual(course, 54.7, 1e-6) {
+		t.Errorf("course = %v, want 54.7", course)
+	}
+	if !almostEqual(speed, 5.5, 1e-6) {
+		t.Errorf("speed = %v, want 5.5", speed)
+	}
+}
+
+func TestParseVTGTooFewFields(t *testing.T) {
+	// A checksum-valid $GPVTG sentence missing the speed/units fields some
+	// receivers omit; it must be rejected instead of indexing past the end
+	// of fields.
+	fields, err := verifyAndSplitNMEA("$GPVTG,054.7,T,034.4,M*4E")
+	if err != nil {
+		t.Fatalf("unexpected error splitting sentence: %v", err)
+	}
+
+	if _, _, err := parseVTG(fields); err == nil {
+		t.Error("expected error for short $GPVTG sentence, got nil")
+	}
+}
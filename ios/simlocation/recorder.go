@@ -0,0 +1,255 @@
+package simlocation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	ios "github.com/danielpaulus/go-ios/ios"
+	log "github.com/sirupsen/logrus"
+)
+
+const recorderSchema = `
+CREATE TABLE IF NOT EXISTS fixes (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	device_udid TEXT    NOT NULL,
+	ts          INTEGER NOT NULL,
+	lat         REAL    NOT NULL,
+	lon         REAL    NOT NULL,
+	source      TEXT    NOT NULL,
+	session_id  TEXT    NOT NULL
+);
+`
+
+// RecorderOptions configures the SQLite track log a recording Connection
+// mirrors SetLocation calls into.
+type RecorderOptions struct {
+	// SessionID groups fixes belonging to one recording run. If empty, one
+	// is generated from the current time.
+	SessionID string
+	// Source tags every row with where the fix came from, e.g. "gpx",
+	// "nmea", "mqtt", "manual". Defaults to "manual".
+	Source string
+	// MaxJumpMeters, when > 0, rejects a fix that is further than this many
+	// meters from the device's previous fix within JumpWindow, the same way
+	// an accuracy-based dedup would drop an implausible outlier. Zero
+	// disables the filter.
+	MaxJumpMeters float64
+	// JumpWindow bounds how long MaxJumpMeters stays in effect after the
+	// previous fix; outside this window a jump is assumed to be legitimate
+	// travel rather than noise. Zero means the filter always applies.
+	JumpWindow time.Duration
+}
+
+type recorder struct {
+	db         *sql.DB
+	deviceUDID string
+	sessionID  string
+	source     string
+	opts       RecorderOptions
+	lastFix    time.Time
+	lastLat    float64
+	lastLon    float64
+	haveLast   bool
+}
+
+// NewRecordingConnection opens (creating if needed) a SQLite database at
+// dbPath and returns a Connection whose every location update is mirrored
+// into it as a row, alongside being sent to the device.
+func NewRecordingConnection(device ios.DeviceEntry, dbPath string) (*Connection, error) {
+	return NewRecordingConnectionWithOptions(device, dbPath, RecorderOptions{})
+}
+
+// NewRecordingConnectionWithOptions is like NewRecordingConnection but allows
+// configuring the session ID, source tag and plausibility filter via opts.
+func NewRecordingConnectionWithOptions(device ios.DeviceEntry, dbPath string, opts RecorderOptions) (*Connection, error) {
+	conn, err := New(device)
+	if err != nil {
+		return conn, err
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		conn.deviceConn.Close()
+		return &Connection{}, fmt.Errorf("opening track log %s: %w", dbPath, err)
+	}
+
+	if _, err := db.Exec(recorderSchema); err != nil {
+		db.Close()
+		conn.deviceConn.Close()
+		return &Connection{}, fmt.Errorf("creating track log schema: %w", err)
+	}
+
+	sessionID := opts.SessionID
+	if sessionID == "" {
+		sessionID = fmt.Sprintf("session-%d", time.Now().UnixNano())
+	}
+
+	source := opts.Source
+	if source == "" {
+		source = "manual"
+	}
+
+	conn.recorder = &recorder{
+		db:         db,
+		deviceUDID: device.Properties.SerialNumber,
+		sessionID:  sessionID,
+		source:     source,
+		opts:       opts,
+	}
+
+	return conn, nil
+}
+
+// record inserts a row for the given fix unless the plausibility filter
+// rejects it as an implausible jump from the previous fix.
+func (r *recorder) record(lat float64, lon float64) error {
+	now := time.Now()
+
+	if r.opts.MaxJumpMeters > 0 && r.haveLast {
+		withinWindow := r.opts.JumpWindow <= 0 || now.Sub(r.lastFix) <= r.opts.JumpWindow
+		if withinWindow {
+			distance := haversineDistanceMeters(r.lastLat, r.lastLon, lat, lon)
+			if distance > r.opts.MaxJumpMeters {
+				log.WithFields(log.Fields{
+					"device_udid":  r.deviceUDID,
+					"distance_m":   distance,
+					"max_jump_m":   r.opts.MaxJumpMeters,
+					"previous_lat": r.lastLat,
+					"previous_lon": r.lastLon,
+				}).Warn("rejecting implausible fix in track log")
+				return nil
+			}
+		}
+	}
+
+	_, err := r.db.Exec(
+		`INSERT INTO fixes (device_udid, ts, lat, lon, source, session_id) VALUES (?, ?, ?, ?, ?, ?)`,
+		r.deviceUDID, now.UnixMilli(), lat, lon, r.source, r.sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("recording fix: %w", err)
+	}
+
+	r.lastFix = now
+	r.lastLat = lat
+	r.lastLon = lon
+	r.haveLast = true
+
+	return nil
+}
+
+type exportedFix struct {
+	ts  int64
+	lat float64
+	lon float64
+}
+
+func querySessionFixes(db *sql.DB, sessionID string) ([]exportedFix, error) {
+	rows, err := db.Query(
+		`SELECT ts, lat, lon FROM fixes WHERE session_id = ? ORDER BY ts ASC`,
+		sessionID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying session %s: %w", sessionID, err)
+	}
+	defer rows.Close()
+
+	var fixes []exportedFix
+	for rows.Next() {
+		var f exportedFix
+		if err := rows.Scan(&f.ts, &f.lat, &f.lon); err != nil {
+			return nil, fmt.Errorf("scanning fix row: %w", err)
+		}
+		fixes = append(fixes, f)
+	}
+
+	return fixes, rows.Err()
+}
+
+// ExportSessionGPX writes every fix recorded under sessionID back out as a
+// single-track GPX document, ordered by timestamp.
+func ExportSessionGPX(db *sql.DB, sessionID string, w io.Writer) error {
+	fixes, err := querySessionFixes(db, sessionID)
+	if err != nil {
+		return err
+	}
+
+	trackPoints := make([]TrackPoint, 0, len(fixes))
+	for _, f := range fixes {
+		trackPoints = append(trackPoints, TrackPoint{
+			PointLatitude:  fmt.Sprintf("%f", f.lat),
+			PointLongitude: fmt.Sprintf("%f", f.lon),
+			PointTime:      time.UnixMilli(f.ts).UTC().Format(time.RFC3339),
+		})
+	}
+
+	gpx := Gpx{
+		Tracks: []Track{
+			{
+				Name:          sessionID,
+				TrackSegments: []TrackSegment{{TrackPoints: trackPoints}},
+			},
+		},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(gpx)
+}
+
+// geoJSONFeatureCollection and geoJSONFeature are a minimal subset of the
+// GeoJSON spec, just enough to represent a session as a LineString.
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   geoJSONLineString      `json:"geometry"`
+}
+
+type geoJSONLineString struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+// ExportSessionGeoJSON writes every fix recorded under sessionID back out as
+// a single GeoJSON LineString feature, ordered by timestamp.
+func ExportSessionGeoJSON(db *sql.DB, sessionID string, w io.Writer) error {
+	fixes, err := querySessionFixes(db, sessionID)
+	if err != nil {
+		return err
+	}
+
+	coordinates := make([][2]float64, 0, len(fixes))
+	for _, f := range fixes {
+		coordinates = append(coordinates, [2]float64{f.lon, f.lat})
+	}
+
+	collection := geoJSONFeatureCollection{
+		Type: "FeatureCollection",
+		Features: []geoJSONFeature{
+			{
+				Type:       "Feature",
+				Properties: map[string]interface{}{"session_id": sessionID},
+				Geometry:   geoJSONLineString{Type: "LineString", Coordinates: coordinates},
+			},
+		},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
@@ -0,0 +1,236 @@
+package simlocation
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestRecorderDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "track.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("opening test db: %v", err)
+	}
+	if _, err := db.Exec(recorderSchema); err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestRecorderRecordInsertsRow(t *testing.T) {
+	db := newTestRecorderDB(t)
+	r := &recorder{db: db, deviceUDID: "udid-1", sessionID: "session-1", source: "manual"}
+
+	if err := r.record(52.520008, 13.404954); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	fixes, err := querySessionFixes(db, "session-1")
+	if err != nil {
+		t.Fatalf("querySessionFixes: %v", err)
+	}
+	if len(fixes) != 1 {
+		t.Fatalf("len(fixes) = %d, want 1", len(fixes))
+	}
+	if !almostEqual(fixes[0].lat, 52.520008, 1e-9) || !almostEqual(fixes[0].lon, 13.404954, 1e-9) {
+		t.Errorf("fixes[0] = %+v, want lat=52.520008 lon=13.404954", fixes[0])
+	}
+}
+
+func TestRecorderPlausibilityFilterFirstFixAlwaysKept(t *testing.T) {
+	db := newTestRecorderDB(t)
+	r := &recorder{
+		db:         db,
+		deviceUDID: "udid-1",
+		sessionID:  "session-1",
+		source:     "manual",
+		opts:       RecorderOptions{MaxJumpMeters: 1},
+	}
+
+	// Even a fix far from (0, 0) must be kept: there is no previous fix yet,
+	// so there is nothing to compare the jump against.
+	if err := r.record(52.520008, 13.404954); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	fixes, err := querySessionFixes(db, "session-1")
+	if err != nil {
+		t.Fatalf("querySessionFixes: %v", err)
+	}
+	if len(fixes) != 1 {
+		t.Fatalf("len(fixes) = %d, want 1", len(fixes))
+	}
+}
+
+func TestRecorderPlausibilityFilterRejectsImplausibleJump(t *testing.T) {
+	db := newTestRecorderDB(t)
+
+	from, to := LatLon{Lat: 52.520008, Lon: 13.404954}, LatLon{Lat: 48.8566, Lon: 2.3522}
+	distance := haversineDistanceMeters(from.Lat, from.Lon, to.Lat, to.Lon)
+
+	r := &recorder{
+		db:         db,
+		deviceUDID: "udid-1",
+		sessionID:  "session-1",
+		source:     "manual",
+		opts:       RecorderOptions{MaxJumpMeters: distance - 1},
+	}
+
+	if err := r.record(from.Lat, from.Lon); err != nil {
+		t.Fatalf("record(from): %v", err)
+	}
+	if err := r.record(to.Lat, to.Lon); err != nil {
+		t.Fatalf("record(to): %v", err)
+	}
+
+	fixes, err := querySessionFixes(db, "session-1")
+	if err != nil {
+		t.Fatalf("querySessionFixes: %v", err)
+	}
+	if len(fixes) != 1 {
+		t.Fatalf("len(fixes) = %d, want 1 (the implausible jump should have been rejected)", len(fixes))
+	}
+}
+
+func TestRecorderPlausibilityFilterKeepsJumpAtExactThreshold(t *testing.T) {
+	db := newTestRecorderDB(t)
+
+	from, to := LatLon{Lat: 52.520008, Lon: 13.404954}, LatLon{Lat: 48.8566, Lon: 2.3522}
+	distance := haversineDistanceMeters(from.Lat, from.Lon, to.Lat, to.Lon)
+
+	r := &recorder{
+		db:         db,
+		deviceUDID: "udid-1",
+		sessionID:  "session-1",
+		source:     "manual",
+		// A jump exactly equal to MaxJumpMeters is not "further than" it, so
+		// it must be kept.
+		opts: RecorderOptions{MaxJumpMeters: distance},
+	}
+
+	if err := r.record(from.Lat, from.Lon); err != nil {
+		t.Fatalf("record(from): %v", err)
+	}
+	if err := r.record(to.Lat, to.Lon); err != nil {
+		t.Fatalf("record(to): %v", err)
+	}
+
+	fixes, err := querySessionFixes(db, "session-1")
+	if err != nil {
+		t.Fatalf("querySessionFixes: %v", err)
+	}
+	if len(fixes) != 2 {
+		t.Fatalf("len(fixes) = %d, want 2 (a jump exactly at the threshold should be kept)", len(fixes))
+	}
+}
+
+func TestRecorderPlausibilityFilterIgnoredOutsideJumpWindow(t *testing.T) {
+	db := newTestRecorderDB(t)
+
+	from, to := LatLon{Lat: 52.520008, Lon: 13.404954}, LatLon{Lat: 48.8566, Lon: 2.3522}
+	distance := haversineDistanceMeters(from.Lat, from.Lon, to.Lat, to.Lon)
+
+	r := &recorder{
+		db:         db,
+		deviceUDID: "udid-1",
+		sessionID:  "session-1",
+		source:     "manual",
+		opts:       RecorderOptions{MaxJumpMeters: distance - 1, JumpWindow: time.Millisecond},
+	}
+
+	if err := r.record(from.Lat, from.Lon); err != nil {
+		t.Fatalf("record(from): %v", err)
+	}
+
+	// Let JumpWindow elapse so the filter no longer treats the next fix as
+	// noise, even though it is still a large jump.
+	time.Sleep(5 * time.Millisecond)
+
+	if err := r.record(to.Lat, to.Lon); err != nil {
+		t.Fatalf("record(to): %v", err)
+	}
+
+	fixes, err := querySessionFixes(db, "session-1")
+	if err != nil {
+		t.Fatalf("querySessionFixes: %v", err)
+	}
+	if len(fixes) != 2 {
+		t.Fatalf("len(fixes) = %d, want 2 (the jump is outside JumpWindow and should be kept)", len(fixes))
+	}
+}
+
+func TestExportSessionGPX(t *testing.T) {
+	db := newTestRecorderDB(t)
+	r := &recorder{db: db, deviceUDID: "udid-1", sessionID: "session-1", source: "manual"}
+
+	if err := r.record(52.520008, 13.404954); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := r.record(48.8566, 2.3522); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportSessionGPX(db, "session-1", &buf); err != nil {
+		t.Fatalf("ExportSessionGPX: %v", err)
+	}
+
+	var gpx Gpx
+	if err := xml.Unmarshal(buf.Bytes(), &gpx); err != nil {
+		t.Fatalf("unmarshaling exported GPX: %v", err)
+	}
+
+	if len(gpx.Tracks) != 1 || len(gpx.Tracks[0].TrackSegments) != 1 {
+		t.Fatalf("gpx = %+v, want a single track with a single segment", gpx)
+	}
+	points := gpx.Tracks[0].TrackSegments[0].TrackPoints
+	if len(points) != 2 {
+		t.Fatalf("len(points) = %d, want 2", len(points))
+	}
+	if points[0].PointLatitude != "52.520008" {
+		t.Errorf("points[0].PointLatitude = %q, want %q", points[0].PointLatitude, "52.520008")
+	}
+}
+
+func TestExportSessionGeoJSON(t *testing.T) {
+	db := newTestRecorderDB(t)
+	r := &recorder{db: db, deviceUDID: "udid-1", sessionID: "session-1", source: "manual"}
+
+	if err := r.record(52.520008, 13.404954); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := r.record(48.8566, 2.3522); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportSessionGeoJSON(db, "session-1", &buf); err != nil {
+		t.Fatalf("ExportSessionGeoJSON: %v", err)
+	}
+
+	var collection geoJSONFeatureCollection
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatalf("unmarshaling exported GeoJSON: %v", err)
+	}
+
+	if len(collection.Features) != 1 {
+		t.Fatalf("len(collection.Features) = %d, want 1", len(collection.Features))
+	}
+	coords := collection.Features[0].Geometry.Coordinates
+	if len(coords) != 2 {
+		t.Fatalf("len(coords) = %d, want 2", len(coords))
+	}
+	// GeoJSON coordinates are [lon, lat].
+	if !almostEqual(coords[0][0], 13.404954, 1e-9) || !almostEqual(coords[0][1], 52.520008, 1e-9) {
+		t.Errorf("coords[0] = %v, want [13.404954, 52.520008]", coords[0])
+	}
+}
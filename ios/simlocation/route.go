@@ -0,0 +1,141 @@
+package simlocation
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	ios "github.com/danielpaulus/go-ios/ios"
+)
+
+// LatLon is a point given in decimal degrees.
+type LatLon struct {
+	Lat float64
+	Lon float64
+}
+
+// RouteProfile selects how SetLocationRoute's speed varies over the course
+// of the route.
+type RouteProfile int
+
+const (
+	// RouteProfileConstant holds SpeedKmh for the entire route.
+	RouteProfileConstant RouteProfile = iota
+	// RouteProfileAccelDecel ramps up from a stop over the first tenth of
+	// the route and ramps back down to a stop over the last tenth, holding
+	// SpeedKmh in between.
+	RouteProfileAccelDecel
+)
+
+// rampFraction is the portion of the route, at the start and at the end,
+// over which RouteProfileAccelDecel ramps speed.
+const rampFraction = 0.1
+
+// RouteOptions configures SetLocationRoute.
+type RouteOptions struct {
+	// SpeedKmh is the target speed in kilometers per hour.
+	SpeedKmh float64
+	// UpdateHz is how many location updates to emit per second. Defaults to 1.
+	UpdateHz float64
+	// Jitter adds Gaussian noise with this standard deviation, in meters, to
+	// every emitted point, to simulate GPS inaccuracy. Zero disables jitter.
+	Jitter float64
+	// Profile selects how speed varies over the route. Defaults to
+	// RouteProfileConstant.
+	Profile RouteProfile
+	// Context allows cancelling a route that is in progress.
+	Context context.Context
+}
+
+// SetLocationRoute drives the device from `from` to `to` along the
+// great-circle path between them, stepping each tick by the distance covered
+// at SpeedKmh in 1/UpdateHz seconds (scaled by Profile), with points computed
+// by the standard destination-point formula. It
+// blocks until the route completes, opts.Context is cancelled, or an update
+// fails.
+func SetLocationRoute(device ios.DeviceEntry, from LatLon, to LatLon, opts RouteOptions) error {
+	if opts.SpeedKmh <= 0 {
+		return errors.New("SpeedKmh must be > 0")
+	}
+
+	hz := opts.UpdateHz
+	if hz <= 0 {
+		hz = 1
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	distance := haversineDistanceMeters(from.Lat, from.Lon, to.Lat, to.Lon)
+	bearing := initialBearingRadians(from.Lat, from.Lon, to.Lat, to.Lon)
+	baseStepMeters := (opts.SpeedKmh * 1000 / 3600) / hz
+	tickInterval := time.Duration(float64(time.Second) / hz)
+
+	conn, err := New(device)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := sendRoutePoint(conn, from.Lat, from.Lon, opts.Jitter); err != nil {
+		return err
+	}
+	if distance == 0 {
+		return nil
+	}
+
+	var traveled float64
+	for traveled < distance {
+		if err := sleepContext(ctx, tickInterval); err != nil {
+			return err
+		}
+
+		speedFactor := routeSpeedFactor(opts.Profile, traveled, distance)
+		traveled += baseStepMeters * speedFactor
+		if traveled > distance {
+			traveled = distance
+		}
+
+		lat, lon := destinationPoint(from.Lat, from.Lon, bearing, traveled)
+		if err := sendRoutePoint(conn, lat, lon, opts.Jitter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendRoutePoint applies jitter (if any) and pushes the resulting point.
+func sendRoutePoint(conn *Connection, lat float64, lon float64, jitterMeters float64) error {
+	if jitterMeters > 0 {
+		noiseBearing := rand.Float64() * 2 * math.Pi
+		noiseDistance := math.Abs(rand.NormFloat64() * jitterMeters)
+		lat, lon = destinationPoint(lat, lon, noiseBearing, noiseDistance)
+	}
+	return conn.Push(lat, lon)
+}
+
+// routeSpeedFactor returns the fraction of SpeedKmh to apply at the given
+// distance already traveled, according to profile.
+func routeSpeedFactor(profile RouteProfile, traveled float64, totalDistance float64) float64 {
+	if profile != RouteProfileAccelDecel || totalDistance == 0 {
+		return 1
+	}
+
+	rampDistance := totalDistance * rampFraction
+	if rampDistance == 0 {
+		return 1
+	}
+
+	if traveled < rampDistance {
+		return math.Max(traveled/rampDistance, 0.05)
+	}
+	if remaining := totalDistance - traveled; remaining < rampDistance {
+		return math.Max(remaining/rampDistance, 0.05)
+	}
+	return 1
+}
@@ -0,0 +1,43 @@
+package simlocation
+
+import "testing"
+
+func TestRouteSpeedFactorConstant(t *testing.T) {
+	for _, traveled := range []float64{0, 100, 500, 999, 1000} {
+		if got := routeSpeedFactor(RouteProfileConstant, traveled, 1000); got != 1 {
+			t.Errorf("routeSpeedFactor(constant, %v, 1000) = %v, want 1", traveled, got)
+		}
+	}
+}
+
+func TestRouteSpeedFactorAccelDecel(t *testing.T) {
+	const totalDistance = 1000
+
+	tests := []struct {
+		name     string
+		traveled float64
+		want     float64
+	}{
+		{"standing start", 0, 0.05},
+		{"midway through the acceleration ramp", 50, 0.5},
+		{"end of the acceleration ramp", 100, 1},
+		{"cruising in the middle", 500, 1},
+		{"midway through the deceleration ramp", 950, 0.5},
+		{"coming to a stop", 1000, 0.05},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := routeSpeedFactor(RouteProfileAccelDecel, tt.traveled, totalDistance)
+			if !almostEqual(got, tt.want, 1e-9) {
+				t.Errorf("routeSpeedFactor(accelDecel, %v, %v) = %v, want %v", tt.traveled, totalDistance, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteSpeedFactorZeroDistance(t *testing.T) {
+	if got := routeSpeedFactor(RouteProfileAccelDecel, 0, 0); got != 1 {
+		t.Errorf("routeSpeedFactor(accelDecel, 0, 0) = %v, want 1", got)
+	}
+}
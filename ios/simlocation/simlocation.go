@@ -2,6 +2,7 @@ package simlocation
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/xml"
 	"errors"
@@ -20,6 +21,10 @@ const serviceName string = "com.apple.dt.simulatelocation"
 type Connection struct {
 	deviceConn ios.DeviceConnectionInterface
 	plistCodec ios.PlistCodec
+	// recorder is non-nil when this Connection was created via
+	// NewRecordingConnection, in which case every Push call also mirrors
+	// the fix into the track log.
+	recorder *recorder
 }
 
 type locationData struct {
@@ -37,6 +42,9 @@ func New(device ios.DeviceEntry) (*Connection, error) {
 
 func (locationConn *Connection) Close() {
 	locationConn.deviceConn.Close()
+	if locationConn.recorder != nil {
+		locationConn.recorder.db.Close()
+	}
 }
 
 // Set the device location to a point by latitude and longitude
@@ -61,13 +69,25 @@ func SetLocation(device ios.DeviceEntry, lat string, lon string) error {
 		return err
 	}
 
-	data := new(locationData)
-	data.lat = latitude
-	data.lon = longitude
-
 	log.WithFields(log.Fields{"latitude": latitude, "longitude": longitude}).
 		Info("Simulating device location")
 
+	if err := locationConn.Push(latitude, longitude); err != nil {
+		return err
+	}
+
+	locationConn.Close()
+	return nil
+}
+
+// Push sends a single location update over this Connection. The socket is
+// safe to hold open for the lifetime of a simulation session: GPX, NMEA,
+// MQTT and route replay all keep one Connection and call Push repeatedly
+// instead of reconnecting for every point. If the Connection was created via
+// NewRecordingConnection, the fix is also mirrored into its track log.
+func (locationConn *Connection) Push(lat float64, lon float64) error {
+	data := &locationData{lat: lat, lon: lon}
+
 	// Generate the byte data needed by the service to set the location
 	locationBytes, err := data.LocationBytes()
 	if err != nil {
@@ -75,12 +95,16 @@ func SetLocation(device ios.DeviceEntry, lat string, lon string) error {
 	}
 
 	// Send the generated byte data for the expected simulated coordinates
-	err = locationConn.deviceConn.Send(locationBytes)
-	if err != nil {
+	if err := locationConn.deviceConn.Send(locationBytes); err != nil {
 		return err
 	}
 
-	locationConn.Close()
+	if locationConn.recorder != nil {
+		if err := locationConn.recorder.record(lat, lon); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -129,6 +153,14 @@ func SetLocationGPX(device ios.DeviceEntry, filePath string) error {
 		return err
 	}
 
+	// Hold a single connection open for the whole replay instead of
+	// reconnecting for every trackpoint.
+	locationConn, err := New(device)
+	if err != nil {
+		return err
+	}
+	defer locationConn.Close()
+
 	var lastPointTime time.Time
 
 	// Loop through all available tracks, their segments and the segments respective track points to cover the whole file
@@ -158,21 +190,261 @@ func SetLocationGPX(device ios.DeviceEntry, filePath string) error {
 
 				// Change the last point time to the time of the currently set point
 				lastPointTime = currentPointTime
-				pointLon := point.PointLongitude
-				pointLat := point.PointLatitude
+
+				lat, err := strconv.ParseFloat(point.PointLatitude, 64)
+				if err != nil {
+					return err
+				}
+				lon, err := strconv.ParseFloat(point.PointLongitude, 64)
+				if err != nil {
+					return err
+				}
 
 				// Set the current point location by its latitude and longitude
-				err = SetLocation(device, pointLat, pointLon)
+				if err := locationConn.Push(lat, lon); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// GPXPlayOptions controls how SetLocationGPXWithOptions replays a GPX
+// recording, beyond the plain wall-clock-accurate replay SetLocationGPX does.
+type GPXPlayOptions struct {
+	// SpeedMultiplier scales the sleep duration between trackpoints, e.g. 2.0
+	// replays the recording twice as fast. Zero or negative is treated as 1.
+	SpeedMultiplier float64
+	// Loop restarts the replay from the first trackpoint once the last one
+	// is reached, running until Context is cancelled.
+	Loop bool
+	// InterpolateHz, when > 0, synthesizes intermediate points between
+	// consecutive trackpoints along their great-circle segment, so the
+	// device sees smooth motion at that rate instead of jumping every few
+	// seconds.
+	InterpolateHz float64
+	// StartOffset skips into the recording, discarding trackpoints whose
+	// timestamp is before the first trackpoint's time plus this duration. If
+	// the offset reaches past the end, only the last trackpoint is replayed.
+	StartOffset time.Duration
+	// MaxPointGap caps the sleep between two trackpoints, so a long pause in
+	// a recording (e.g. the device was parked for 30 minutes) doesn't hang
+	// the replay. Zero means no cap.
+	MaxPointGap time.Duration
+	// Context allows cancelling a replay that is sleeping between points or
+	// looping indefinitely. A nil Context is treated as context.Background().
+	Context context.Context
+}
+
+// SetLocationGPXWithOptions replays a GPX recording like SetLocationGPX, but
+// allows time-warping, looping and interpolating between trackpoints via
+// opts. See GPXPlayOptions for details.
+func SetLocationGPXWithOptions(device ios.DeviceEntry, filePath string, opts GPXPlayOptions) error {
+	gpxFile, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer gpxFile.Close()
+
+	byteData, err := io.ReadAll(gpxFile)
+	if err != nil {
+		return err
+	}
+
+	var gpx Gpx
+	err = xml.Unmarshal(byteData, &gpx)
+	if err != nil {
+		return err
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	speedMultiplier := opts.SpeedMultiplier
+	if speedMultiplier <= 0 {
+		speedMultiplier = 1
+	}
+
+	points, err := flattenTrackPoints(gpx)
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return errors.New("gpx file does not contain any track points")
+	}
+
+	if opts.StartOffset > 0 {
+		points = skipToOffset(points, opts.StartOffset)
+	}
+
+	// Hold a single connection open for the whole replay instead of
+	// reconnecting for every trackpoint.
+	locationConn, err := New(device)
+	if err != nil {
+		return err
+	}
+	defer locationConn.Close()
+
+	for {
+		var lastPointTime time.Time
+		previousSegmentPaced := false
+		for i, point := range points {
+			if !lastPointTime.IsZero() && !previousSegmentPaced {
+				gap := point.time.Sub(lastPointTime)
+				if opts.MaxPointGap > 0 && gap > opts.MaxPointGap {
+					gap = opts.MaxPointGap
+				}
+				gap = time.Duration(float64(gap) / speedMultiplier)
+
+				if gap > 0 {
+					if err := sleepContext(ctx, gap); err != nil {
+						return err
+					}
+				}
+			}
+			lastPointTime = point.time
+			previousSegmentPaced = false
+
+			if opts.InterpolateHz > 0 && i+1 < len(points) {
+				// replayInterpolated already paces itself out across the
+				// segment, so the next iteration must not sleep for the same
+				// gap again.
+				if err := replayInterpolated(locationConn, point, points[i+1], opts.InterpolateHz, speedMultiplier, opts.MaxPointGap, ctx); err != nil {
+					return err
+				}
+				previousSegmentPaced = true
+			} else {
+				lat, lon, err := point.parseLatLon()
 				if err != nil {
 					return err
 				}
+				if err := locationConn.Push(lat, lon); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !opts.Loop {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+type gpxPoint struct {
+	lat  string
+	lon  string
+	time time.Time
+}
+
+func (p gpxPoint) parseLatLon() (lat float64, lon float64, err error) {
+	lat, err = strconv.ParseFloat(p.lat, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	lon, err = strconv.ParseFloat(p.lon, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return lat, lon, nil
+}
+
+// flattenTrackPoints walks all tracks/segments of a parsed GPX document into
+// a single slice of points, in document order; points are not sorted by time.
+func flattenTrackPoints(gpx Gpx) ([]gpxPoint, error) {
+	var points []gpxPoint
+	for _, track := range gpx.Tracks {
+		for _, segment := range track.TrackSegments {
+			for _, point := range segment.TrackPoints {
+				pointTime, err := time.Parse(time.RFC3339, point.PointTime)
+				if err != nil {
+					return nil, err
+				}
+				points = append(points, gpxPoint{lat: point.PointLatitude, lon: point.PointLongitude, time: pointTime})
 			}
 		}
 	}
+	return points, nil
+}
+
+// skipToOffset drops points before the first point's time plus offset. If
+// every point is before that, only the last point is kept.
+func skipToOffset(points []gpxPoint, offset time.Duration) []gpxPoint {
+	if len(points) == 0 {
+		return points
+	}
+	cutoff := points[0].time.Add(offset)
+	for i, point := range points {
+		if !point.time.Before(cutoff) {
+			return points[i:]
+		}
+	}
+	return points[len(points)-1:]
+}
+
+// replayInterpolated synthesizes and pushes intermediate points between from
+// and to at InterpolateHz, using great-circle interpolation, over conn. When
+// maxGap > 0 the segment is replayed over at most maxGap.
+func replayInterpolated(conn *Connection, from gpxPoint, to gpxPoint, hz float64, speedMultiplier float64, maxGap time.Duration, ctx context.Context) error {
+	lat1, lon1, err := from.parseLatLon()
+	if err != nil {
+		return err
+	}
+	lat2, lon2, err := to.parseLatLon()
+	if err != nil {
+		return err
+	}
+
+	segmentDuration := to.time.Sub(from.time)
+	if segmentDuration <= 0 {
+		return conn.Push(lat1, lon1)
+	}
+	if maxGap > 0 && segmentDuration > maxGap {
+		segmentDuration = maxGap
+	}
+
+	step := time.Duration(float64(time.Second) / hz)
+	steps := int(segmentDuration / step)
+	if steps < 1 {
+		return conn.Push(lat1, lon1)
+	}
+
+	for i := 0; i < steps; i++ {
+		fraction := float64(i) / float64(steps)
+		lat, lon := interpolateGreatCircle(lat1, lon1, lat2, lon2, fraction)
+
+		if err := conn.Push(lat, lon); err != nil {
+			return err
+		}
+
+		if err := sleepContext(ctx, time.Duration(float64(step)/speedMultiplier)); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// sleepContext sleeps for d, or returns early with ctx.Err() if ctx is
+// cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func ResetLocation(device ios.DeviceEntry) error {
 	// Create a new connection to the location service
 	locationConn, err := New(device)
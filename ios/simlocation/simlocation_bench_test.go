@@ -0,0 +1,56 @@
+package simlocation
+
+import (
+	"testing"
+
+	ios "github.com/danielpaulus/go-ios/ios"
+)
+
+// These benchmarks require a real device attached over USB with a
+// developer-mounted disk image, since that's what the simulatelocation
+// service talks to. They are skipped automatically when none is reachable.
+
+// BenchmarkSetLocationReconnect exercises the old pattern of opening a fresh
+// Connection for every update.
+func BenchmarkSetLocationReconnect(b *testing.B) {
+	device := benchDevice(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := SetLocation(device, "52.520008", "13.404954"); err != nil {
+			b.Fatalf("SetLocation: %v", err)
+		}
+	}
+}
+
+// BenchmarkConnectionPush exercises the long-lived Connection pattern, which
+// all of GPX, NMEA, MQTT and route replay now use.
+func BenchmarkConnectionPush(b *testing.B) {
+	device := benchDevice(b)
+
+	conn, err := New(device)
+	if err != nil {
+		b.Fatalf("New: %v", err)
+	}
+	defer conn.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := conn.Push(52.520008, 13.404954); err != nil {
+			b.Fatalf("Push: %v", err)
+		}
+	}
+}
+
+// benchDevice returns the first attached device, or skips the benchmark if
+// none is reachable.
+func benchDevice(b *testing.B) ios.DeviceEntry {
+	b.Helper()
+
+	deviceList, err := ios.ListDevices()
+	if err != nil || len(deviceList.DeviceList) == 0 {
+		b.Skip("no attached device available to benchmark against")
+	}
+
+	return deviceList.DeviceList[0]
+}